@@ -0,0 +1,53 @@
+// Package httpmw provides a chi-native OpenTelemetry middleware. It
+// must be mounted after chi.NewRouter() (e.g. via r.Use) so that by the
+// time it runs the response has been handled, chi has finished
+// matching, and chi.RouteContext(r.Context()).RoutePattern() reflects
+// the matched route template rather than the raw URL path.
+package httpmw
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusRecorder wraps http.ResponseWriter to capture the status code
+// the handler wrote, defaulting to 200 if WriteHeader is never called.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware renames the span otelhttp started for this request to
+// "METHOD {route pattern}", sets http.route per semconv, and marks the
+// span as errored on 5xx responses.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		pattern := chi.RouteContext(r.Context()).RoutePattern()
+		if pattern == "" {
+			pattern = r.URL.Path
+		}
+
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(fmt.Sprintf("%s %s", r.Method, pattern))
+		span.SetAttributes(semconv.HTTPRoute(pattern))
+
+		if rec.status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("http: %d %s", rec.status, http.StatusText(rec.status)))
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
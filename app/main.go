@@ -3,27 +3,37 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/Msksgm/curl-otel-nginx-web-app/httpmw"
+	"github.com/Msksgm/curl-otel-nginx-web-app/telemetry"
+	"github.com/Msksgm/curl-otel-nginx-web-app/users"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
-var tracer trace.Tracer
+const defaultBaseURL = "http://localhost:8080"
+
+var meter metric.Meter
+var helloRequests metric.Int64Counter
+var userRepo users.Repository
+var tracedClient *http.Client
+var baseURL string
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -31,81 +41,27 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func newExporter(ctx context.Context) (*otlptrace.Exporter, error) {
-	// Get New Relic OTLP endpoint from environment variable or use default
-	endpoint := os.Getenv("NEW_RELIC_OTLP_ENDPOINT")
-	if endpoint == "" {
-		return nil, fmt.Errorf("NEW_RELIC_OTLP_ENDPOINT environment variable is required")
-	}
-
-	// Get New Relic API key from environment variable
-	apiKey := os.Getenv("NEW_RELIC_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("NEW_RELIC_API_KEY environment variable is required")
-	}
-
-	log.Printf("Initializing OpenTelemetry with New Relic endpoint: %s", endpoint)
-
-	// Create OTLP trace exporter with New Relic configuration
-	exporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithEndpoint(endpoint),
-		otlptracegrpc.WithHeaders(map[string]string{
-			"Accept":  "*/*",
-			"api-key": apiKey,
-		}),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-	}
-
-	return exporter, nil
-}
-
-func newTracerProvider(exp sdktrace.SpanExporter) *sdktrace.TracerProvider {
-	// Create resource with service information
-	res, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName("go-app"),
-		),
-	)
-	if err != nil {
-		panic(err)
-	}
-
-	// Create TracerProvider
-	return sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(res),
-	)
-}
-
+// getHealtz, and every other handler below, just annotates the span
+// otelhttp started and ends; httpmw.Middleware renames it to the matched
+// route pattern afterwards, but otelhttp's own deferred span.End() is
+// what ends it.
 func getHealtz(w http.ResponseWriter, r *http.Request) {
-	// The context already contains trace information from otelhttp middleware
-	ctx := r.Context()
-	_, span := tracer.Start(ctx, "health_check")
-	defer span.End()
+	span := trace.SpanFromContext(r.Context())
 
 	span.SetAttributes(attribute.String("http.target", r.URL.Path))
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
 func getRoot(w http.ResponseWriter, r *http.Request) {
-	// The context already contains trace information from otelhttp middleware
-	ctx := r.Context()
-	_, span := tracer.Start(ctx, "root_handler")
-	defer span.End()
+	span := trace.SpanFromContext(r.Context())
 
 	span.SetAttributes(attribute.String("http.target", r.URL.Path))
 	fmt.Fprintln(w, "Welcome to the chi HTTP server behind Nginx!")
 }
 
 func getHello(w http.ResponseWriter, r *http.Request) {
-	// The context already contains trace information from otelhttp middleware
 	ctx := r.Context()
-	_, span := tracer.Start(ctx, "hello_handler")
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	// Log trace information for debugging
 	if spanCtx := span.SpanContext(); spanCtx.IsValid() {
@@ -121,39 +77,147 @@ func getHello(w http.ResponseWriter, r *http.Request) {
 		attribute.String("http.target", r.URL.Path),
 		attribute.String("hello.name", name),
 	)
+	helloRequests.Add(ctx, 1)
 	writeJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("Hello, %s!", name)})
 }
 
 func getUserByID(w http.ResponseWriter, r *http.Request) {
-	// The context already contains trace information from otelhttp middleware
 	ctx := r.Context()
-	_, span := tracer.Start(ctx, "get_user_handler")
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
 
 	id := chi.URLParam(r, "id")
 	span.SetAttributes(
 		attribute.String("http.target", r.URL.Path),
 		attribute.String("user.id", id),
 	)
-	writeJSON(w, http.StatusOK, map[string]any{"id": id, "profile": map[string]any{"nickname": "guest", "created_at": time.Now().UTC()}})
+
+	user, err := userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, users.ErrNotFound) {
+			// A 404 is an expected client outcome, not a server fault:
+			// leave the span status Unset so it doesn't skew error-rate
+			// views, matching the 5xx-only policy httpmw.Middleware uses.
+			span.SetAttributes(attribute.Bool("user.not_found", true))
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"id": user.ID, "profile": map[string]any{"nickname": user.Nickname, "created_at": user.CreatedAt}})
+}
+
+// getAggregate fans out to /hello and /users/{id} over tracedClient so
+// the resulting trace shows child client spans under this server span,
+// demonstrating propagation through Nginx and back into the same
+// service.
+func getAggregate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("http.target", r.URL.Path))
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		id = "1"
+	}
+
+	hello, err := fetchJSON(ctx, baseURL+"/hello")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to fetch /hello"})
+		return
+	}
+
+	user, err := fetchJSON(ctx, baseURL+"/users/"+id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to fetch /users/" + id})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"hello": hello, "user": user})
+}
+
+// fetchJSON issues a GET through tracedClient, propagating ctx so the
+// request carries the W3C traceparent/baggage of the current span.
+func fetchJSON(ctx context.Context, url string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", url, err)
+	}
+
+	resp, err := tracedClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return body, nil
 }
 
 func main() {
+	// Register W3C tracecontext + baggage propagation before anything
+	// starts a span, otherwise otelhttp.WithPropagators falls back to a
+	// no-op propagator and context never crosses the wire.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
 	// Initialize OpenTelemetry
 	ctx := context.Background()
 
-	exp, err := newExporter(ctx)
+	telClient, err := telemetry.New(ctx)
+	if err != nil {
+		log.Fatalf("failed to initialize telemetry: %v", err)
+	}
+
+	meter = telClient.Meter()
+
+	helloRequests, err = meter.Int64Counter(
+		"app.hello.requests_total",
+		metric.WithDescription("Number of requests handled by the /hello endpoint"),
+	)
 	if err != nil {
-		log.Fatalf("failed to create exporter: %v", err)
+		log.Fatalf("failed to create app.hello.requests_total counter: %v", err)
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
 	}
 
-	tp := newTracerProvider(exp)
+	userPostgres, err := users.NewPostgresRepository(ctx, databaseURL)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+	defer userPostgres.Close()
 
-	defer func() { _ = tp.Shutdown(ctx) }()
+	userRepo = userPostgres
 
-	otel.SetTracerProvider(tp)
+	baseURL = os.Getenv("SELF_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
 
-	tracer = tp.Tracer("go-app")
+	tracedClient = &http.Client{
+		Transport: otelhttp.NewTransport(
+			http.DefaultTransport,
+			otelhttp.WithTracerProvider(telClient.TracerProvider()),
+			otelhttp.WithPropagators(otel.GetTextMapPropagator()),
+		),
+		Timeout: 10 * time.Second,
+	}
 
 	// Create chi router
 	r := chi.NewRouter()
@@ -161,11 +225,16 @@ func main() {
 	// Add chi middleware for logging
 	r.Use(middleware.Logger)
 
+	// Rename the otelhttp server span to "METHOD {route pattern}" once
+	// chi has matched the request, instead of the raw URL path.
+	r.Use(httpmw.Middleware)
+
 	// Define routes
 	r.Get("/healthz", getHealtz)
 	r.Get("/", getRoot)
 	r.Get("/hello", getHello)
 	r.Get("/users/{id}", getUserByID)
+	r.Get("/aggregate", getAggregate)
 
 	// Wrap handler with OpenTelemetry HTTP instrumentation with proper options
 	handler := otelhttp.NewHandler(
@@ -176,7 +245,8 @@ func main() {
 			return fmt.Sprintf("%s %s", r.Method, r.URL.Path)
 		}),
 		otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents),
-		otelhttp.WithTracerProvider(otel.GetTracerProvider()),
+		otelhttp.WithTracerProvider(telClient.TracerProvider()),
+		otelhttp.WithMeterProvider(telClient.MeterProvider()),
 		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
 	)
 
@@ -197,11 +267,45 @@ func main() {
 	}()
 
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
-
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
 	log.Println("shutting down...")
-	_ = srv.Shutdown(shutdownCtx)
+
+	timeout := shutdownTimeout()
+
+	srvCtx, srvCancel := context.WithTimeout(context.Background(), timeout)
+	defer srvCancel()
+	if err := srv.Shutdown(srvCtx); err != nil {
+		log.Printf("error shutting down http server: %v", err)
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), timeout)
+	defer flushCancel()
+	flushed, err := telClient.ForceFlush(flushCtx)
+	if err != nil {
+		log.Printf("error flushing telemetry: %v", err)
+	}
+	log.Printf("flushed %d spans", flushed)
+
+	telShutdownCtx, telShutdownCancel := context.WithTimeout(context.Background(), timeout)
+	defer telShutdownCancel()
+	if err := telClient.Shutdown(telShutdownCtx); err != nil {
+		log.Printf("error shutting down telemetry: %v", err)
+	}
+}
+
+// shutdownTimeout returns the per-stage shutdown timeout from
+// SHUTDOWN_TIMEOUT (seconds), defaulting to 10s.
+func shutdownTimeout() time.Duration {
+	raw := os.Getenv("SHUTDOWN_TIMEOUT")
+	if raw == "" {
+		return 10 * time.Second
+	}
+
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		log.Printf("invalid SHUTDOWN_TIMEOUT %q, using default of 10s", raw)
+		return 10 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
 }
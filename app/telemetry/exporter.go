@@ -0,0 +1,190 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exporterKind selects which backend newTraceExporter/newMetricExporter
+// talk to. It is read from OTEL_EXPORTER so developers can run the
+// service locally against a Jaeger all-in-one or stdout without New
+// Relic credentials.
+type exporterKind string
+
+const (
+	exporterOTLPGRPC exporterKind = "otlpgrpc"
+	exporterOTLPHTTP exporterKind = "otlphttp"
+	exporterStdout   exporterKind = "stdout"
+	exporterNone     exporterKind = "none"
+)
+
+func exporterKindFromEnv() exporterKind {
+	switch exporterKind(os.Getenv("OTEL_EXPORTER")) {
+	case exporterOTLPHTTP:
+		return exporterOTLPHTTP
+	case exporterStdout:
+		return exporterStdout
+	case exporterNone:
+		return exporterNone
+	case exporterOTLPGRPC, "":
+		return exporterOTLPGRPC
+	default:
+		return exporterOTLPGRPC
+	}
+}
+
+// otlpTarget resolves the endpoint and headers an OTLP exporter should
+// use. It prefers the standard OTEL_EXPORTER_OTLP_ENDPOINT/
+// OTEL_EXPORTER_OTLP_HEADERS pair and falls back to the New Relic
+// specific env vars this service originally shipped with.
+func otlpTarget() (endpoint string, headers map[string]string, err error) {
+	if endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return endpoint, parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")), nil
+	}
+
+	endpoint = os.Getenv("NEW_RELIC_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return "", nil, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT or NEW_RELIC_OTLP_ENDPOINT environment variable is required")
+	}
+
+	apiKey := os.Getenv("NEW_RELIC_API_KEY")
+	if apiKey == "" {
+		return "", nil, fmt.Errorf("NEW_RELIC_API_KEY environment variable is required")
+	}
+
+	return endpoint, map[string]string{
+		"Accept":  "*/*",
+		"api-key": apiKey,
+	}, nil
+}
+
+// parseOTLPHeaders parses the W3C-Correlation-Context-style list the
+// OTLP env var spec uses: "key1=value1,key2=value2".
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+func newTraceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch exporterKindFromEnv() {
+	case exporterOTLPHTTP:
+		endpoint, headers, err := otlpTarget()
+		if err != nil {
+			return nil, err
+		}
+		return otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithHeaders(headers),
+		)
+	case exporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case exporterNone:
+		return newNoopSpanExporter(), nil
+	default:
+		endpoint, headers, err := otlpTarget()
+		if err != nil {
+			return nil, err
+		}
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithHeaders(headers),
+		)
+	}
+}
+
+func newMetricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	switch exporterKindFromEnv() {
+	case exporterOTLPHTTP:
+		endpoint, headers, err := otlpTarget()
+		if err != nil {
+			return nil, err
+		}
+		return otlpmetrichttp.New(ctx,
+			otlpmetrichttp.WithEndpoint(endpoint),
+			otlpmetrichttp.WithHeaders(headers),
+		)
+	case exporterStdout:
+		return stdoutmetric.New()
+	case exporterNone:
+		return newNoopMetricExporter(), nil
+	default:
+		endpoint, headers, err := otlpTarget()
+		if err != nil {
+			return nil, err
+		}
+		return otlpmetricgrpc.New(ctx,
+			otlpmetricgrpc.WithEndpoint(endpoint),
+			otlpmetricgrpc.WithHeaders(headers),
+		)
+	}
+}
+
+// batcherOptions builds the sdktrace.WithBatcher options from the
+// standard OTEL_BSP_* env vars, falling back to the SDK defaults when
+// unset or unparsable.
+func batcherOptions() []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+
+	if v, ok := durationEnv("OTEL_BSP_SCHEDULE_DELAY"); ok {
+		opts = append(opts, sdktrace.WithBatchTimeout(v))
+	}
+	if v, ok := durationEnv("OTEL_BSP_EXPORT_TIMEOUT"); ok {
+		opts = append(opts, sdktrace.WithExportTimeout(v))
+	}
+	if v, ok := intEnv("OTEL_BSP_MAX_EXPORT_BATCH_SIZE"); ok {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(v))
+	}
+	if v, ok := intEnv("OTEL_BSP_MAX_QUEUE_SIZE"); ok {
+		opts = append(opts, sdktrace.WithMaxQueueSize(v))
+	}
+
+	return opts
+}
+
+func durationEnv(name string) (time.Duration, bool) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, false
+	}
+	ms, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+func intEnv(name string) (int, bool) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string]string
+	}{
+		{name: "empty", raw: "", want: nil},
+		{
+			name: "single pair",
+			raw:  "api-key=secret",
+			want: map[string]string{"api-key": "secret"},
+		},
+		{
+			name: "multiple pairs with whitespace",
+			raw:  "api-key=secret, Accept = */*",
+			want: map[string]string{"api-key": "secret", "Accept": "*/*"},
+		},
+		{
+			name: "malformed pair without '=' is skipped",
+			raw:  "api-key=secret,malformed,Accept=*/*",
+			want: map[string]string{"api-key": "secret", "Accept": "*/*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseOTLPHeaders(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOTLPHeaders(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExporterKindFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want exporterKind
+	}{
+		{name: "unset defaults to otlpgrpc", env: "", want: exporterOTLPGRPC},
+		{name: "otlpgrpc", env: "otlpgrpc", want: exporterOTLPGRPC},
+		{name: "otlphttp", env: "otlphttp", want: exporterOTLPHTTP},
+		{name: "stdout", env: "stdout", want: exporterStdout},
+		{name: "none", env: "none", want: exporterNone},
+		{name: "unknown value falls back to otlpgrpc", env: "bogus", want: exporterOTLPGRPC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER", tt.env)
+
+			if got := exporterKindFromEnv(); got != tt.want {
+				t.Errorf("exporterKindFromEnv() with OTEL_EXPORTER=%q = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// noopSpanExporter backs OTEL_EXPORTER=none: it discards every span
+// instead of failing startup when no backend is configured.
+type noopSpanExporter struct{}
+
+func newNoopSpanExporter() sdktrace.SpanExporter { return noopSpanExporter{} }
+
+func (noopSpanExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error { return nil }
+func (noopSpanExporter) Shutdown(context.Context) error                            { return nil }
+
+// noopMetricExporter backs OTEL_EXPORTER=none for metrics.
+type noopMetricExporter struct{}
+
+func newNoopMetricExporter() metric.Exporter { return noopMetricExporter{} }
+
+func (noopMetricExporter) Temporality(metric.InstrumentKind) metricdata.Temporality {
+	return metricdata.CumulativeTemporality
+}
+
+func (noopMetricExporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+func (noopMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error { return nil }
+func (noopMetricExporter) ForceFlush(context.Context) error                          { return nil }
+func (noopMetricExporter) Shutdown(context.Context) error                            { return nil }
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const defaultSamplerRatio = 0.1
+
+// routeOverrides pins specific routes to their own sampling ratio
+// regardless of the base sampler decision. /healthz is excluded
+// entirely so liveness/readiness probes don't spam the backend.
+var routeOverrides = map[string]float64{
+	"/healthz": 0,
+}
+
+// samplerFromEnv builds the TracerProvider sampler from OTEL_TRACES_SAMPLER
+// and OTEL_TRACES_SAMPLER_ARG, defaulting to a parent-based trace-ID-ratio
+// sampler at 0.1 so sampled parents (e.g. Nginx's W3C traceparent) are
+// always recorded while uninstrumented traffic is rate-limited. The
+// result is wrapped so routeOverrides always win regardless of the base
+// sampler's decision.
+func samplerFromEnv() sdktrace.Sampler {
+	return newRouteOverrideSampler(baseSamplerFromEnv(), routeOverrides)
+}
+
+func baseSamplerFromEnv() sdktrace.Sampler {
+	ratio := samplerRatioFromEnv()
+
+	switch os.Getenv("OTEL_TRACES_SAMPLER") {
+	case "always_on":
+		return sdktrace.AlwaysSample()
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio)
+	case "parentbased_traceidratio", "":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	default:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	}
+}
+
+func samplerRatioFromEnv() float64 {
+	raw := os.Getenv("OTEL_TRACES_SAMPLER_ARG")
+	if raw == "" {
+		return defaultSamplerRatio
+	}
+
+	ratio, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return defaultSamplerRatio
+	}
+	return ratio
+}
+
+// routeOverrideSampler wraps a base sampler and forces a per-route
+// sampling ratio for routes present in overrides. Sampling runs in
+// tracer.Start, before otelhttp has chi's matched route pattern, so the
+// only thing available to key off is the route parsed out of the
+// "METHOD /path" span name set by otelhttp's span name formatter — this
+// only works for literal, param-free paths (like /healthz), not for
+// patterns such as /users/{id}.
+type routeOverrideSampler struct {
+	base      sdktrace.Sampler
+	overrides map[string]float64
+}
+
+func newRouteOverrideSampler(base sdktrace.Sampler, overrides map[string]float64) sdktrace.Sampler {
+	return &routeOverrideSampler{base: base, overrides: overrides}
+}
+
+func (s *routeOverrideSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if route := routeFromSamplingParameters(p); route != "" {
+		if ratio, ok := s.overrides[route]; ok {
+			return sdktrace.TraceIDRatioBased(ratio).ShouldSample(p)
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s *routeOverrideSampler) Description() string {
+	return fmt.Sprintf("RouteOverrideSampler{%s}", s.base.Description())
+}
+
+// routeFromSamplingParameters recovers the request path from the span
+// name, formatted by otelhttp as "METHOD /path".
+func routeFromSamplingParameters(p sdktrace.SamplingParameters) string {
+	if _, path, ok := strings.Cut(p.Name, " "); ok {
+		return path
+	}
+	return ""
+}
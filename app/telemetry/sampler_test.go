@@ -0,0 +1,66 @@
+package telemetry
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// paramsForRoute mirrors the span name otelhttp's formatter produces,
+// "METHOD /path" — the only thing routeFromSamplingParameters has to
+// key off at sampling time.
+func paramsForRoute(route string) sdktrace.SamplingParameters {
+	return sdktrace.SamplingParameters{
+		Name: "GET " + route,
+	}
+}
+
+func TestRouteOverrideSamplerDropsHealthz(t *testing.T) {
+	sampler := newRouteOverrideSampler(sdktrace.AlwaysSample(), routeOverrides)
+
+	result := sampler.ShouldSample(paramsForRoute("/healthz"))
+	if result.Decision != sdktrace.Drop {
+		t.Errorf("ShouldSample(/healthz) decision = %v, want Drop", result.Decision)
+	}
+}
+
+func TestRouteOverrideSamplerFallsBackToBase(t *testing.T) {
+	sampler := newRouteOverrideSampler(sdktrace.AlwaysSample(), routeOverrides)
+
+	result := sampler.ShouldSample(paramsForRoute("/hello"))
+	if result.Decision != sdktrace.RecordAndSample {
+		t.Errorf("ShouldSample(/hello) decision = %v, want RecordAndSample", result.Decision)
+	}
+}
+
+func TestBaseSamplerFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want sdktrace.SamplingDecision
+	}{
+		{name: "always_on", env: "always_on", want: sdktrace.RecordAndSample},
+		{name: "always_off", env: "always_off", want: sdktrace.Drop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_SAMPLER", tt.env)
+
+			got := baseSamplerFromEnv().ShouldSample(paramsForRoute("/hello")).Decision
+			if got != tt.want {
+				t.Errorf("baseSamplerFromEnv() with OTEL_TRACES_SAMPLER=%s decision = %v, want %v", tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseSamplerFromEnvTraceIDRatio(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER", "traceidratio")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "1")
+
+	got := baseSamplerFromEnv().ShouldSample(paramsForRoute("/hello")).Decision
+	if got != sdktrace.RecordAndSample {
+		t.Errorf("baseSamplerFromEnv() with OTEL_TRACES_SAMPLER=traceidratio, ARG=1 decision = %v, want RecordAndSample", got)
+	}
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// countingSpanProcessor wraps another SpanProcessor and counts how many
+// spans have ended, so shutdown can report how many were flushed.
+type countingSpanProcessor struct {
+	next  sdktrace.SpanProcessor
+	count atomic.Int64
+}
+
+func newCountingSpanProcessor(next sdktrace.SpanProcessor) *countingSpanProcessor {
+	return &countingSpanProcessor{next: next}
+}
+
+func (p *countingSpanProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(ctx, s)
+}
+
+func (p *countingSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.count.Add(1)
+	p.next.OnEnd(s)
+}
+
+func (p *countingSpanProcessor) Shutdown(ctx context.Context) error { return p.next.Shutdown(ctx) }
+
+func (p *countingSpanProcessor) ForceFlush(ctx context.Context) error { return p.next.ForceFlush(ctx) }
+
+// Count returns the number of spans that have ended so far.
+func (p *countingSpanProcessor) Count() int64 { return p.count.Load() }
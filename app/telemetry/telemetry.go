@@ -0,0 +1,145 @@
+// Package telemetry wires up the OpenTelemetry tracing and metrics
+// pipelines shared by the app. It mirrors the shape used by the Docker CLI
+// telemetry package: a single client owns both providers, a shared
+// resource, and one Shutdown that flushes everything together.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// instrumentationName is the meter name, and the resource's service.name
+// attribute when OTEL_SERVICE_NAME is unset.
+const instrumentationName = "go-app"
+
+// TelemetryClient owns the TracerProvider and MeterProvider for the
+// service and flushes/shuts both down together.
+type TelemetryClient interface {
+	TracerProvider() *sdktrace.TracerProvider
+	MeterProvider() *sdkmetric.MeterProvider
+	Meter() metric.Meter
+	// ForceFlush blocks until any buffered spans/metrics are exported and
+	// returns how many spans have ended so far.
+	ForceFlush(ctx context.Context) (flushedSpans int64, err error)
+	Shutdown(ctx context.Context) error
+}
+
+type client struct {
+	tp          *sdktrace.TracerProvider
+	mp          *sdkmetric.MeterProvider
+	spanCounter *countingSpanProcessor
+}
+
+// New creates a TelemetryClient wired to the exporters selected by
+// OTEL_EXPORTER (see exporter.go), registers both providers as the
+// global providers, and starts collecting Go runtime metrics (GC,
+// goroutines, heap) on a periodic reader.
+func New(ctx context.Context) (TelemetryClient, error) {
+	res, err := newResource()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	traceExp, err := newTraceExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	metricExp, err := newMetricExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	spanCounter := newCountingSpanProcessor(sdktrace.NewBatchSpanProcessor(traceExp, batcherOptions()...))
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(spanCounter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(samplerFromEnv()),
+	)
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExp, sdkmetric.WithInterval(15*time.Second))),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+
+	if err := runtime.Start(runtime.WithMeterProvider(mp)); err != nil {
+		return nil, fmt.Errorf("failed to start runtime metrics collection: %w", err)
+	}
+
+	return &client{tp: tp, mp: mp, spanCounter: spanCounter}, nil
+}
+
+// serviceName honors the standard OTEL_SERVICE_NAME env var, falling
+// back to the service's default name.
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return instrumentationName
+}
+
+func newResource() (*resource.Resource, error) {
+	return resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName()),
+		),
+	)
+}
+
+// Meter returns the service's named Meter.
+func (c *client) Meter() metric.Meter { return c.mp.Meter(instrumentationName) }
+
+// TracerProvider returns the underlying SDK TracerProvider.
+func (c *client) TracerProvider() *sdktrace.TracerProvider { return c.tp }
+
+// MeterProvider returns the underlying SDK MeterProvider.
+func (c *client) MeterProvider() *sdkmetric.MeterProvider { return c.mp }
+
+// ForceFlush exports any spans/metrics buffered so far and reports how
+// many spans have ended, so callers can log it during shutdown.
+func (c *client) ForceFlush(ctx context.Context) (int64, error) {
+	var flushErr error
+	if err := c.tp.ForceFlush(ctx); err != nil {
+		flushErr = fmt.Errorf("failed to flush tracer provider: %w", err)
+	}
+	if err := c.mp.ForceFlush(ctx); err != nil {
+		if flushErr != nil {
+			return c.spanCounter.Count(), fmt.Errorf("%w; failed to flush meter provider: %v", flushErr, err)
+		}
+		flushErr = fmt.Errorf("failed to flush meter provider: %w", err)
+	}
+	return c.spanCounter.Count(), flushErr
+}
+
+// Shutdown flushes any buffered spans and metrics and shuts both
+// providers down, returning the first error encountered.
+func (c *client) Shutdown(ctx context.Context) error {
+	var shutdownErr error
+	if err := c.tp.Shutdown(ctx); err != nil {
+		shutdownErr = fmt.Errorf("failed to shut down tracer provider: %w", err)
+	}
+	if err := c.mp.Shutdown(ctx); err != nil {
+		if shutdownErr != nil {
+			return fmt.Errorf("%w; failed to shut down meter provider: %v", shutdownErr, err)
+		}
+		shutdownErr = fmt.Errorf("failed to shut down meter provider: %w", err)
+	}
+	return shutdownErr
+}
@@ -0,0 +1,36 @@
+package users
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryRepository is an in-memory Repository used by tests.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// NewMemoryRepository returns a MemoryRepository seeded with the given
+// users, keyed by their ID.
+func NewMemoryRepository(seed ...User) *MemoryRepository {
+	users := make(map[string]User, len(seed))
+	for _, u := range seed {
+		users[u.ID] = u
+	}
+	return &MemoryRepository{users: users}
+}
+
+// GetByID implements Repository.
+func (r *MemoryRepository) GetByID(ctx context.Context, id string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+var _ Repository = (*MemoryRepository)(nil)
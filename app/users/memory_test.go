@@ -0,0 +1,34 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Msksgm/curl-otel-nginx-web-app/users"
+)
+
+func TestMemoryRepositoryGetByID(t *testing.T) {
+	repo := users.NewMemoryRepository(users.User{
+		ID:        "1",
+		Nickname:  "guest",
+		CreatedAt: time.Unix(0, 0).UTC(),
+	})
+
+	got, err := repo.GetByID(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetByID() returned unexpected error: %v", err)
+	}
+	if got.Nickname != "guest" {
+		t.Errorf("Nickname = %q, want %q", got.Nickname, "guest")
+	}
+}
+
+func TestMemoryRepositoryGetByIDNotFound(t *testing.T) {
+	repo := users.NewMemoryRepository()
+
+	if _, err := repo.GetByID(context.Background(), "missing"); !errors.Is(err, users.ErrNotFound) {
+		t.Fatalf("GetByID() error = %v, want %v", err, users.ErrNotFound)
+	}
+}
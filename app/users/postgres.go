@@ -0,0 +1,58 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresRepository is a Repository backed by Postgres. Every query is
+// traced via otelpgx, so the GET /users/{id} server span gets a child
+// db.query span with db.system/db.statement/db.name attributes.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository parses databaseURL, attaches an otelpgx tracer
+// to the connection config, and opens a pool against it.
+func NewPostgresRepository(ctx context.Context, databaseURL string) (*PostgresRepository, error) {
+	cfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DATABASE_URL: %w", err)
+	}
+	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	return &PostgresRepository{pool: pool}, nil
+}
+
+// Close releases the underlying connection pool.
+func (r *PostgresRepository) Close() {
+	r.pool.Close()
+}
+
+// GetByID implements Repository.
+func (r *PostgresRepository) GetByID(ctx context.Context, id string) (User, error) {
+	var u User
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, nickname, created_at FROM users WHERE id = $1`, id,
+	).Scan(&u.ID, &u.Nickname, &u.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrNotFound
+		}
+		return User{}, fmt.Errorf("failed to query user %s: %w", id, err)
+	}
+
+	return u, nil
+}
+
+var _ Repository = (*PostgresRepository)(nil)
@@ -0,0 +1,26 @@
+// Package users provides the user-lookup service layer: a Repository
+// interface backed by Postgres in production and an in-memory
+// implementation for tests.
+package users
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// User is a single user profile.
+type User struct {
+	ID        string
+	Nickname  string
+	CreatedAt time.Time
+}
+
+// ErrNotFound is returned by a Repository when no user matches the
+// requested ID.
+var ErrNotFound = errors.New("user not found")
+
+// Repository fetches user profiles by ID.
+type Repository interface {
+	GetByID(ctx context.Context, id string) (User, error)
+}